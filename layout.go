@@ -0,0 +1,116 @@
+package strftime
+
+import (
+	"fmt"
+	"strings"
+)
+
+// layoutTokens maps a strftime directive to the equivalent Go reference
+// time token, for directives that have one. Directives with no Go layout
+// equivalent (%j, %k, %l, %s, %U, %W, %w, %C, %G, %g, %V, %u, and the %[1-9]n
+// nanosecond form) are deliberately absent; Layout reports an error for
+// them.
+var layoutTokens = map[string]string{
+	"%a": "Mon",
+	"%A": "Monday",
+	"%b": "Jan",
+	"%B": "January",
+	"%c": "Mon, 02 Jan 2006 15:04:05 MST",
+	"%d": "02",
+	"%D": "01/02/06",
+	"%e": "_2",
+	"%F": "2006-01-02",
+	"%h": "Jan",
+	"%H": "15",
+	"%I": "03",
+	"%m": "01",
+	"%M": "04",
+	"%n": "\n",
+	"%p": "PM",
+	"%P": "pm",
+	"%r": "03:04:05 PM",
+	"%R": "15:04",
+	"%S": "05",
+	"%t": "\t",
+	"%T": "15:04:05",
+	"%v": "_2-Jan-2006",
+	"%x": "01/02/06",
+	"%X": "15:04:05",
+	"%y": "06",
+	"%Y": "2006",
+	"%Z": "MST",
+	"%z": "-0700",
+}
+
+// magicSubstrings are the Go reference-time tokens that time.Parse and
+// time.Time.Format recognize anywhere in a layout string. A literal run
+// that happens to contain one would be mistaken for a field by the stdlib,
+// so Layout rejects it rather than silently producing a broken layout.
+var magicSubstrings = []string{"Jan", "Mon", "MST", "PM", "pm"}
+
+// checkLiteralSafe reports an error if literal, a run of the format string
+// that is not part of a directive, contains text the Go layout parser would
+// mistake for a reference field. offset is literal's byte position in the
+// original format string, used to make the error actionable.
+func checkLiteralSafe(literal string, offset int) error {
+	for _, magic := range magicSubstrings {
+		if idx := strings.Index(literal, magic); idx >= 0 {
+			return fmt.Errorf("strftime: Layout: literal %q at byte %d collides with Go reference layout token %q", literal, offset+idx, magic)
+		}
+	}
+	for i := 0; i < len(literal); i++ {
+		if literal[i] >= '0' && literal[i] <= '9' {
+			return fmt.Errorf("strftime: Layout: literal %q at byte %d contains a digit, which Go's layout parser would read as part of a reference field", literal, offset+i)
+		}
+	}
+	return nil
+}
+
+// Layout translates a strftime format into an equivalent Go reference time
+// layout, so the result can be passed to time.Parse, time.Time.Format, or
+// any other API that only understands Go's reference-time layouts.
+//
+// Layout returns an error if format uses a directive with no Go layout
+// equivalent (%j, %k, %l, %s, %U, %W, %w, %C, %G, %g, %V, %u, %[1-9]n), or if
+// a literal run of format contains text ("Jan", "Mon", "MST", "PM", "pm",
+// or a digit) that Go's layout parser would otherwise misinterpret as a
+// reference field.
+func Layout(format string) (string, error) {
+	var buf strings.Builder
+	pos := 0
+	for pos < len(format) {
+		idx := strings.IndexByte(format[pos:], '%')
+		if idx < 0 {
+			if err := checkLiteralSafe(format[pos:], pos); err != nil {
+				return "", err
+			}
+			buf.WriteString(format[pos:])
+			break
+		}
+		if idx > 0 {
+			literal := format[pos : pos+idx]
+			if err := checkLiteralSafe(literal, pos); err != nil {
+				return "", err
+			}
+			buf.WriteString(literal)
+			pos += idx
+		}
+
+		if pos+1 >= len(format) {
+			return "", fmt.Errorf("strftime: Layout: dangling %% at byte %d", pos)
+		}
+		spec := format[pos : pos+2]
+		if spec == "%%" {
+			buf.WriteByte('%')
+			pos += 2
+			continue
+		}
+		token, ok := layoutTokens[spec]
+		if !ok {
+			return "", fmt.Errorf("strftime: Layout: %s has no Go reference layout equivalent", spec)
+		}
+		buf.WriteString(token)
+		pos += 2
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,69 @@
+package strftime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLayout(t *testing.T) {
+	cases := []struct {
+		format, layout string
+	}{
+		{"%Y-%m-%d %H:%M:%S", "2006-01-02 15:04:05"},
+		{"%Y/%m/%d", "2006/01/02"},
+		{"%I:%M %p", "03:04 PM"},
+		{"%a, %d %b %Y %H:%M:%S %z", "Mon, 02 Jan 2006 15:04:05 -0700"},
+		{"%Y%%", "2006%"},
+		{"%F", "2006-01-02"},
+		{"%T", "15:04:05"},
+		{"%D", "01/02/06"},
+		{"%R", "15:04"},
+		{"%r", "03:04:05 PM"},
+		{"%v", "_2-Jan-2006"},
+		{"%Y%n%m%t%d", "2006\n01\t02"},
+		{"%e-%h-%Y", "_2-Jan-2006"},
+		{"%I:%M %P", "03:04 pm"},
+	}
+	for _, tc := range cases {
+		layout, err := Layout(tc.format)
+		if err != nil {
+			t.Fatalf("Layout(%q) returned error: %v", tc.format, err)
+		}
+		if layout != tc.layout {
+			t.Fatalf("Layout(%q) = %q, want %q", tc.format, layout, tc.layout)
+		}
+	}
+
+	parsed, err := Layout("%Y-%m-%d %H:%M:%S")
+	if err != nil {
+		t.Fatalf("Layout returned error: %v", err)
+	}
+	tm, err := time.Parse(parsed, "2009-11-10 23:01:02")
+	if err != nil {
+		t.Fatalf("time.Parse with translated layout failed: %v", err)
+	}
+	if tm.Year() != 2009 || tm.Month() != 11 || tm.Day() != 10 {
+		t.Fatalf("unexpected parsed time: %v", tm)
+	}
+}
+
+func TestLayout_UnsupportedDirective(t *testing.T) {
+	for _, spec := range []string{"%j", "%k", "%l", "%U", "%W", "%w", "%C", "%G", "%g", "%V", "%u"} {
+		if _, err := Layout(spec); err == nil {
+			t.Fatalf("expected error for %s, which has no Go layout equivalent", spec)
+		}
+	}
+}
+
+func TestLayout_LiteralCollision(t *testing.T) {
+	cases := []string{
+		"Jan %Y",
+		"at 3pm on %d",
+		"report-1-%Y",
+	}
+	for _, format := range cases {
+		if _, err := Layout(format); err == nil {
+			t.Fatalf("Layout(%q): expected a collision error, got none", format)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package strftime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var parseMonthNames = [...]string{
+	"January", "February", "March", "April", "May", "June",
+	"July", "August", "September", "October", "November", "December",
+}
+
+var parseWeekdayNames = [...]string{
+	"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday",
+}
+
+// Parse parses value according to format, the inverse of Format.
+//
+// When format translates cleanly to a Go reference layout (see Layout),
+// Parse delegates to time.Parse. Directives with no Go layout equivalent
+// (%j, %k, %l, %U, %W, %w, %s, %[1-9]n, %C, and the ISO 8601 week fields %G/%g/%V/%u)
+// are handled by a hand-written scanner instead. In the absence of a time
+// zone indicator in format, Parse returns a time in UTC, matching
+// time.Parse.
+func Parse(format, value string, opts ...Option) (time.Time, error) {
+	return parseIn(format, value, time.UTC, opts...)
+}
+
+// ParseInLocation is like Parse but interprets value in loc when format
+// carries no explicit time zone (%z).
+func ParseInLocation(format, value string, loc *time.Location, opts ...Option) (time.Time, error) {
+	return parseIn(format, value, loc, opts...)
+}
+
+func parseIn(format, value string, loc *time.Location, opts ...Option) (time.Time, error) {
+	o := newOptions(opts)
+	if layout, err := Layout(format); err == nil {
+		return time.ParseInLocation(layout, value, loc)
+	}
+	st, err := scanValue(o.specs, format, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return st.resolve(loc)
+}
+
+// parseState accumulates the fields a scan over format extracts from value,
+// so they can be reconciled into a single time.Time once the whole pattern
+// has been consumed.
+type parseState struct {
+	year, century, yy                        int
+	haveYear, haveCentury, haveYY            bool
+	month                                    time.Month
+	day                                      int
+	yday                                     int
+	haveYDay                                 bool
+	hour, min, sec, nsec                     int
+	hour12, havePM, pm                       bool
+	zoneOffset                               int
+	haveZoneOffset                           bool
+	unixSec                                  int64
+	haveUnix                                 bool
+	isoYear, isoWeek, isoWeekday             int
+	haveISOYear, haveISOWeek, haveISOWeekday bool
+}
+
+// scanValue walks format the same way appendToken does, but in reverse:
+// each directive consumes a run of value instead of producing one.
+func scanValue(specs *SpecificationSet, format, value string) (*parseState, error) {
+	st := &parseState{month: time.January, day: 1}
+	var err error
+	for _, tok := range tokenize(specs, format) {
+		switch {
+		case tok.spec == "":
+			if !strings.HasPrefix(value, tok.literal) {
+				return nil, fmt.Errorf("strftime: Parse: expected %q, found %q", tok.literal, value)
+			}
+			value = value[len(tok.literal):]
+			continue
+		case tok.spec == "%%":
+			if !strings.HasPrefix(value, "%") {
+				return nil, fmt.Errorf("strftime: Parse: expected %%, found %q", value)
+			}
+			value = value[1:]
+			continue
+		case isNanoSpec(tok.spec):
+			width := int(tok.spec[1] - '0')
+			if len(value) < width {
+				return nil, fmt.Errorf("strftime: Parse: expected a %d-digit fraction, found %q", width, value)
+			}
+			n, convErr := strconv.Atoi(value[:width])
+			if convErr != nil {
+				return nil, convErr
+			}
+			st.nsec = n * pow10(9-width)
+			value = value[width:]
+			continue
+		}
+
+		switch tok.spec {
+		case "%Y":
+			st.year, value, err = consumeUnsignedDigits(value, 6)
+			st.haveYear = true
+		case "%y":
+			st.yy, value, err = consumeUnsignedDigits(value, 2)
+			st.haveYY = true
+		case "%C":
+			st.century, value, err = consumeUnsignedDigits(value, 2)
+			st.haveCentury = true
+		case "%G":
+			st.isoYear, value, err = consumeUnsignedDigits(value, 6)
+			st.haveISOYear = true
+		case "%g":
+			var yy int
+			yy, value, err = consumeUnsignedDigits(value, 2)
+			st.isoYear = 2000 + yy
+			st.haveISOYear = true
+		case "%V":
+			st.isoWeek, value, err = consumeUnsignedDigits(value, 2)
+			st.haveISOWeek = true
+		case "%u":
+			st.isoWeekday, value, err = consumeUnsignedDigits(value, 1)
+			st.haveISOWeekday = true
+		case "%w":
+			var w int
+			w, value, err = consumeUnsignedDigits(value, 1)
+			if w == 0 {
+				w = 7
+			}
+			st.isoWeekday, st.haveISOWeekday = w, true
+		case "%m":
+			var m int
+			m, value, err = consumeUnsignedDigits(value, 2)
+			st.month = time.Month(m)
+		case "%d", "%e":
+			st.day, value, err = consumeUnsignedDigits(value, 2)
+		case "%j":
+			st.yday, value, err = consumeUnsignedDigits(value, 3)
+			st.haveYDay = true
+		case "%H", "%k":
+			st.hour, value, err = consumeUnsignedDigits(value, 2)
+		case "%I", "%l":
+			st.hour, value, err = consumeUnsignedDigits(value, 2)
+			st.hour12 = true
+		case "%M":
+			st.min, value, err = consumeUnsignedDigits(value, 2)
+		case "%S":
+			st.sec, value, err = consumeUnsignedDigits(value, 2)
+		case "%s":
+			st.unixSec, value, err = consumeSignedDigits(value)
+			st.haveUnix = true
+		case "%p", "%P":
+			var idx int
+			idx, value, err = consumeName(value, []string{"AM", "PM"})
+			st.havePM, st.pm = true, idx == 1
+		case "%b", "%B", "%h":
+			var idx int
+			idx, value, err = consumeName(value, parseMonthNames[:])
+			st.month = time.Month(idx + 1)
+		case "%a", "%A":
+			_, value, err = consumeName(value, parseWeekdayNames[:])
+		case "%z":
+			st.zoneOffset, value, err = consumeZoneOffset(value)
+			st.haveZoneOffset = true
+		case "%Z":
+			value, err = consumeZoneName(value)
+		case "%U", "%W":
+			_, value, err = consumeUnsignedDigits(value, 2)
+		default:
+			return nil, fmt.Errorf("strftime: Parse: %s cannot be parsed", tok.spec)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if value != "" {
+		return nil, fmt.Errorf("strftime: Parse: unconsumed input %q", value)
+	}
+	return st, nil
+}
+
+// resolve reconciles the fields scanValue collected into a single time.Time.
+func (st *parseState) resolve(loc *time.Location) (time.Time, error) {
+	if st.haveUnix {
+		return time.Unix(st.unixSec, int64(st.nsec)).In(loc), nil
+	}
+
+	zoneLoc := loc
+	if st.haveZoneOffset {
+		zoneLoc = time.FixedZone("", st.zoneOffset)
+	}
+	hour := st.resolvedHour()
+
+	if st.haveISOYear && st.haveISOWeek {
+		weekday := st.isoWeekday
+		if !st.haveISOWeekday {
+			weekday = 1
+		}
+		date := isoWeekToDate(st.isoYear, st.isoWeek, weekday)
+		return time.Date(date.Year(), date.Month(), date.Day(), hour, st.min, st.sec, st.nsec, zoneLoc), nil
+	}
+
+	year := st.year
+	if !st.haveYear && (st.haveCentury || st.haveYY) {
+		year = st.century*100 + st.yy
+		if !st.haveCentury {
+			// Same two-digit pivot as time.Parse: 69-99 are 19xx, 00-68 are 20xx.
+			year = 1900 + st.yy
+			if st.yy < 69 {
+				year = 2000 + st.yy
+			}
+		}
+	}
+
+	if st.haveYDay {
+		date := time.Date(year, time.January, 1, 0, 0, 0, 0, zoneLoc).AddDate(0, 0, st.yday-1)
+		return time.Date(date.Year(), date.Month(), date.Day(), hour, st.min, st.sec, st.nsec, zoneLoc), nil
+	}
+
+	return time.Date(year, st.month, st.day, hour, st.min, st.sec, st.nsec, zoneLoc), nil
+}
+
+func (st *parseState) resolvedHour() int {
+	hour := st.hour
+	if st.hour12 && st.havePM {
+		if st.pm && hour != 12 {
+			hour += 12
+		}
+		if !st.pm && hour == 12 {
+			hour = 0
+		}
+	}
+	return hour
+}
+
+// isoWeekToDate converts an ISO 8601 week date (year, week, weekday with 1
+// being Monday) to a Gregorian date, via the year's first Thursday.
+func isoWeekToDate(year, week, weekday int) time.Time {
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	jan4Weekday := int(jan4.Weekday())
+	if jan4Weekday == 0 {
+		jan4Weekday = 7
+	}
+	week1Monday := jan4.AddDate(0, 0, -(jan4Weekday - 1))
+	return week1Monday.AddDate(0, 0, (week-1)*7+(weekday-1))
+}
+
+func pow10(n int) int {
+	p := 1
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+	return p
+}
+
+func consumeUnsignedDigits(value string, maxWidth int) (int, string, error) {
+	value = strings.TrimLeft(value, " ")
+	i := 0
+	for i < len(value) && i < maxWidth && value[i] >= '0' && value[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return 0, value, fmt.Errorf("strftime: Parse: expected a number, found %q", value)
+	}
+	n, err := strconv.Atoi(value[:i])
+	return n, value[i:], err
+}
+
+func consumeSignedDigits(value string) (int64, string, error) {
+	rest := value
+	neg := false
+	if len(rest) > 0 && (rest[0] == '+' || rest[0] == '-') {
+		neg = rest[0] == '-'
+		rest = rest[1:]
+	}
+	i := 0
+	for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return 0, value, fmt.Errorf("strftime: Parse: expected a number, found %q", value)
+	}
+	n, err := strconv.ParseInt(rest[:i], 10, 64)
+	if err != nil {
+		return 0, value, err
+	}
+	if neg {
+		n = -n
+	}
+	return n, rest[i:], nil
+}
+
+func consumeName(value string, names []string) (int, string, error) {
+	for i, name := range names {
+		if len(value) >= len(name) && strings.EqualFold(value[:len(name)], name) {
+			return i, value[len(name):], nil
+		}
+	}
+	for i, name := range names {
+		if len(name) >= 3 && len(value) >= 3 && strings.EqualFold(value[:3], name[:3]) {
+			return i, value[3:], nil
+		}
+	}
+	return 0, value, fmt.Errorf("strftime: Parse: expected a name, found %q", value)
+}
+
+func consumeZoneOffset(value string) (int, string, error) {
+	if strings.HasPrefix(value, "Z") {
+		return 0, value[1:], nil
+	}
+	if len(value) < 5 || (value[0] != '+' && value[0] != '-') {
+		return 0, value, fmt.Errorf("strftime: Parse: invalid time zone offset %q", value)
+	}
+	sign := value[0]
+	rest := value[1:]
+	width := 5
+	if len(rest) >= 5 && rest[2] == ':' {
+		width = 6
+	}
+	if len(rest) < width-1 {
+		return 0, value, fmt.Errorf("strftime: Parse: invalid time zone offset %q", value)
+	}
+	var hh, mm int
+	var err error
+	if width == 6 {
+		hh, err = strconv.Atoi(rest[0:2])
+		if err == nil {
+			mm, err = strconv.Atoi(rest[3:5])
+		}
+	} else {
+		hh, err = strconv.Atoi(rest[0:2])
+		if err == nil {
+			mm, err = strconv.Atoi(rest[2:4])
+		}
+	}
+	if err != nil {
+		return 0, value, err
+	}
+	offset := hh*3600 + mm*60
+	if sign == '-' {
+		offset = -offset
+	}
+	return offset, value[1+width-1:], nil
+}
+
+func consumeZoneName(value string) (string, error) {
+	i := 0
+	for i < len(value) && ((value[i] >= 'A' && value[i] <= 'Z') || (value[i] >= 'a' && value[i] <= 'z')) {
+		i++
+	}
+	if i == 0 {
+		return value, fmt.Errorf("strftime: Parse: expected a time zone name, found %q", value)
+	}
+	return value[i:], nil
+}
@@ -0,0 +1,158 @@
+package strftime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_Layout(t *testing.T) {
+	cases := []struct {
+		format, value string
+		want          time.Time
+	}{
+		{"%Y-%m-%d %H:%M:%S", "2009-11-10 23:01:02", time.Date(2009, time.November, 10, 23, 1, 2, 0, time.UTC)},
+		{"%Y/%m/%d", "2009/11/10", time.Date(2009, time.November, 10, 0, 0, 0, 0, time.UTC)},
+		{"%I:%M %p", "11:01 PM", time.Date(0, time.January, 1, 23, 1, 0, 0, time.UTC)},
+		{"%I:%M %p", "03:01 AM", time.Date(0, time.January, 1, 3, 1, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		got, err := Parse(tc.format, tc.value)
+		if err != nil {
+			t.Fatalf("Parse(%q, %q) returned error: %v", tc.format, tc.value, err)
+		}
+		if !got.Equal(tc.want) {
+			t.Fatalf("Parse(%q, %q) = %v, want %v", tc.format, tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestParse_NoGoLayoutEquivalent(t *testing.T) {
+	cases := []struct {
+		format, value string
+		want          time.Time
+	}{
+		{"%Y-%j", "2009-314", time.Date(2009, time.November, 10, 0, 0, 0, 0, time.UTC)},
+		{"%s", "1257894062", time.Date(2009, time.November, 10, 23, 1, 2, 0, time.UTC)},
+		{"%Y-%m-%d %3n", "2009-11-10 003", time.Date(2009, time.November, 10, 0, 0, 0, 3000000, time.UTC)},
+		{"%G-W%V-%u", "2009-W46-2", time.Date(2009, time.November, 10, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		got, err := Parse(tc.format, tc.value)
+		if err != nil {
+			t.Fatalf("Parse(%q, %q) returned error: %v", tc.format, tc.value, err)
+		}
+		if !got.Equal(tc.want) {
+			t.Fatalf("Parse(%q, %q) = %v, want %v", tc.format, tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestParse_RoundTripsFormat(t *testing.T) {
+	formats := []string{
+		"%Y-%m-%d %H:%M:%S",
+		"%s",
+	}
+	for _, format := range formats {
+		value := Format(format, testTime)
+		got, err := Parse(format, value)
+		if err != nil {
+			t.Fatalf("Parse(%q, %q) returned error: %v", format, value, err)
+		}
+		if got.Unix() != testTime.Unix() {
+			t.Fatalf("round trip of %q: got %v, want %v", format, got, testTime)
+		}
+	}
+
+	// %Y-%j carries no time-of-day field, so only the date round-trips.
+	value := Format("%Y-%j", testTime)
+	got, err := Parse("%Y-%j", value)
+	if err != nil {
+		t.Fatalf("Parse(%q, %q) returned error: %v", "%Y-%j", value, err)
+	}
+	if got.Year() != testTime.Year() || got.YearDay() != testTime.YearDay() {
+		t.Fatalf("round trip of %%Y-%%j: got %v, want date of %v", got, testTime)
+	}
+
+	// A single-digit 12-hour value regression-tests %I's zero padding: an
+	// unpadded %I would produce "3:00" for Format but Layout/Parse expect
+	// the zero-padded "03" form.
+	singleDigitHour := time.Date(2009, time.November, 10, 3, 0, 0, 0, time.UTC)
+	value = Format("%I:%M %p", singleDigitHour)
+	got, err = Parse("%I:%M %p", value)
+	if err != nil {
+		t.Fatalf("Parse(%q, %q) returned error: %v", "%I:%M %p", value, err)
+	}
+	if got.Hour() != singleDigitHour.Hour() || got.Minute() != singleDigitHour.Minute() {
+		t.Fatalf("round trip of %%I:%%M %%p: got %v, want time of %v", got, singleDigitHour)
+	}
+}
+
+// TestParse_CompositeDirectives round-trips the %F/%T/%D/%R/%r/%v composite
+// directives and the %n/%t literal escapes through Layout, mirroring the
+// equivalents Format already supports for each.
+func TestParse_CompositeDirectives(t *testing.T) {
+	dateCases := []string{"%F", "%D", "%v"}
+	for _, format := range dateCases {
+		value := Format(format, testTime)
+		got, err := Parse(format, value)
+		if err != nil {
+			t.Fatalf("Parse(%q, %q) returned error: %v", format, value, err)
+		}
+		if got.Year() != testTime.Year() || got.Month() != testTime.Month() || got.Day() != testTime.Day() {
+			t.Fatalf("round trip of %q: got %v, want date of %v", format, got, testTime)
+		}
+	}
+
+	timeCases := []string{"%T", "%r"}
+	for _, format := range timeCases {
+		value := Format(format, testTime)
+		got, err := Parse(format, value)
+		if err != nil {
+			t.Fatalf("Parse(%q, %q) returned error: %v", format, value, err)
+		}
+		if got.Hour() != testTime.Hour() || got.Minute() != testTime.Minute() || got.Second() != testTime.Second() {
+			t.Fatalf("round trip of %q: got %v, want time of %v", format, got, testTime)
+		}
+	}
+
+	value := Format("%R", testTime)
+	got, err := Parse("%R", value)
+	if err != nil {
+		t.Fatalf("Parse(%q, %q) returned error: %v", "%R", value, err)
+	}
+	if got.Hour() != testTime.Hour() || got.Minute() != testTime.Minute() {
+		t.Fatalf("round trip of %%R: got %v, want time of %v", got, testTime)
+	}
+
+	value = Format("%Y%n%m%t%d", testTime)
+	got, err = Parse("%Y%n%m%t%d", value)
+	if err != nil {
+		t.Fatalf("Parse(%q, %q) returned error: %v", "%Y%n%m%t%d", value, err)
+	}
+	if got.Year() != testTime.Year() || got.Month() != testTime.Month() || got.Day() != testTime.Day() {
+		t.Fatalf("round trip of %%Y%%n%%m%%t%%d: got %v, want date of %v", got, testTime)
+	}
+}
+
+func TestParseInLocation(t *testing.T) {
+	loc := time.FixedZone("TEST", 3600)
+	got, err := ParseInLocation("%Y-%m-%d %H:%M:%S", "2009-11-10 23:01:02", loc)
+	if err != nil {
+		t.Fatalf("ParseInLocation returned error: %v", err)
+	}
+	want := time.Date(2009, time.November, 10, 23, 1, 2, 0, loc)
+	if !got.Equal(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestParse_Error(t *testing.T) {
+	if _, err := Parse("%Y-%m-%d", "not-a-date"); err == nil {
+		t.Fatalf("expected an error parsing a malformed value")
+	}
+	if _, err := Parse("%Y-%j", "2009-400"); err != nil {
+		// Out-of-range yday is still numeric, so it parses; AddDate just
+		// rolls into the following year rather than erroring.
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -2,32 +2,78 @@
 Implementation of Python's strftime in Go
 
 Example:
-    str, err := strftime.Format("%Y/%m/%d", time.Now()) // 2012/12/07
+
+	str := strftime.Format("%Y/%m/%d", time.Now()) // 2012/12/07
 
 Directives:
-    %a - Locale’s abbreviated weekday name
-    %A - Locale’s full weekday name
-    %b - Locale’s abbreviated month name
-    %B - Locale’s full month name
-    %c - Locale’s appropriate date and time representation
-    %d - Day of the month as a decimal number [01,31]
-    %H - Hour (24-hour clock) as a decimal number [00,23]
-    %I - Hour (12-hour clock) as a decimal number [01,12]
-    %j - Day of year
-    %m - Month as a decimal number [01,12]
-    %M - Minute as a decimal number [00,59]
-    %p - Locale’s equivalent of either AM or PM
-    %S - Second as a decimal number [00,61]
-    %U - Week number of the year
-    %w - Weekday as a decimal number
-    %W - Week number of the year
-    %x - Locale’s appropriate date representation
-    %X - Locale’s appropriate time representation
-    %y - Year without century as a decimal number [00,99]
-    %Y - Year with century as a decimal number
-    %Z - Time zone name (no characters if no time zone exists)
+
+	%a - Locale’s abbreviated weekday name
+	%A - Locale’s full weekday name
+	%b - Locale’s abbreviated month name
+	%B - Locale’s full month name
+	%c - Locale’s appropriate date and time representation
+	%C - Century as a decimal number [00,99]
+	%d - Day of the month as a decimal number [01,31]
+	%D - Equivalent to %m/%d/%y
+	%e - Day of the month as a decimal number, space padded [ 1,31]
+	%F - Equivalent to %Y-%m-%d
+	%g - ISO 8601 week-based year without century as a decimal number [00,99]
+	%G - ISO 8601 week-based year with century as a decimal number
+	%h - Equivalent to %b
+	%H - Hour (24-hour clock) as a decimal number [00,23]
+	%I - Hour (12-hour clock) as a decimal number [01,12]
+	%j - Day of year
+	%k - Hour (24-hour clock) as a decimal number, space padded [ 0,23]
+	%l - Hour (12-hour clock) as a decimal number, space padded [ 1,12]
+	%m - Month as a decimal number [01,12]
+	%M - Minute as a decimal number [00,59]
+	%n - Newline
+	%p - Locale’s equivalent of either AM or PM
+	%P - Like %p but lowercase
+	%r - Equivalent to %I:%M:%S %p
+	%R - Equivalent to %H:%M
+	%s - Number of seconds since the Epoch (1970-01-01 00:00:00 UTC)
+	%S - Second as a decimal number [00,61]
+	%t - Tab
+	%T - Equivalent to %H:%M:%S
+	%u - Weekday as a decimal number [1,7], with 1 being Monday (ISO 8601)
+	%U - Week number of the year
+	%v - Equivalent to %e-%b-%Y
+	%V - ISO 8601 week number
+	%w - Weekday as a decimal number
+	%W - Week number of the year
+	%x - Locale’s appropriate date representation
+	%X - Locale’s appropriate time representation
+	%y - Year without century as a decimal number [00,99]
+	%Y - Year with century as a decimal number
+	%z - Numeric time zone offset (e.g. -0700; +0000 for UTC)
+	%Z - Time zone name (no characters if no time zone exists)
 
 Note that %c returns RFC1123 which is a bit different from what Python does
+
+Between the % and the directive letter, a POSIX/GNU flag may appear to
+adjust padding or case: `-` removes padding, `_` pads with spaces, `0` pads
+with zeros, and `^` upper-cases the result. For instance %-d prints "7"
+instead of "07", and %^a prints "TUE" instead of "Tue".
+
+The set of %X directives understood by Format/NewFormatter is pluggable: see
+SpecificationSet and WithSpecificationSet for registering custom or
+overriding directives without forking the package.
+
+Layout translates a strftime format into an equivalent Go reference time
+layout, for callers who need to hand the result to time.Parse or another API
+that only understands Go's reference-time layouts.
+
+AppendFormat appends to a caller-supplied buffer instead of returning a new
+string, and Formatter precompiles a format into byte-append closures, so
+repeated formatting (e.g. in a logger's hot path) need not allocate on every
+call.
+
+Parse and ParseInLocation are the inverse of Format: they parse a value
+produced by a strftime format back into a time.Time, using Layout and
+time.Parse where possible and falling back to a hand-written scanner for
+directives with no Go layout equivalent (%j, %k, %l, %U, %W, %w, %s,
+%[1-9]n, %C, and the ISO 8601 week fields %G/%g/%V/%u).
 */
 package strftime
 
@@ -35,7 +81,9 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -43,109 +91,261 @@ const (
 	WEEK = time.Hour * 24 * 7
 )
 
-type FormatFunc func(t time.Time) string
+// Appender formats t and appends the result to buf, returning the extended
+// buffer. It is the building block of a SpecificationSet: implementations
+// should avoid allocating when possible, following the convention of
+// time.Time.AppendFormat.
+type Appender func(buf []byte, t time.Time) []byte
+
+// weekNumberSundayFormatter implements %U: the week number of the year,
+// with Sunday as the first day of the week. Days before the year's first
+// Sunday are week 00. This is the standard POSIX algorithm, not a
+// day-by-day walk, so it agrees with Python/glibc for any time zone.
+func weekNumberSundayFormatter(buf []byte, t time.Time) []byte {
+	yday := t.YearDay() - 1
+	wday := int(t.Weekday())
+	week := (yday - wday + 7) / 7
+	return append(buf, fmt.Sprintf("%02d", week)...)
+}
+
+// weekNumberMondayFormatter implements %W: the week number of the year,
+// with Monday as the first day of the week. Days before the year's first
+// Monday are week 00.
+func weekNumberMondayFormatter(buf []byte, t time.Time) []byte {
+	yday := t.YearDay() - 1
+	wday := (int(t.Weekday()) + 6) % 7 // Monday=0 ... Sunday=6
+	week := (yday - wday + 7) / 7
+	return append(buf, fmt.Sprintf("%02d", week)...)
+}
+
+func isoWeekYearFormatter(buf []byte, t time.Time) []byte {
+	year, _ := t.ISOWeek()
+	return append(buf, fmt.Sprintf("%04d", year)...)
+}
+
+func isoWeekYearShortFormatter(buf []byte, t time.Time) []byte {
+	year, _ := t.ISOWeek()
+	return append(buf, fmt.Sprintf("%02d", year%100)...)
+}
 
-func weekNumberFormatter(t time.Time) string {
-	start := time.Date(t.Year(), time.January, 1, 23, 0, 0, 0, time.UTC)
-	week := 0
-	for start.Before(t) {
-		week += 1
-		start = start.Add(WEEK)
+func isoWeekFormatter(buf []byte, t time.Time) []byte {
+	_, week := t.ISOWeek()
+	return append(buf, fmt.Sprintf("%02d", week)...)
+}
+
+func isoWeekdayFormatter(buf []byte, t time.Time) []byte {
+	weekday := int(t.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	return append(buf, fmt.Sprintf("%d", weekday)...)
+}
+
+func hour24SpacePaddedFormatter(buf []byte, t time.Time) []byte {
+	return append(buf, fmt.Sprintf("%2d", t.Hour())...)
+}
+
+func hour12SpacePaddedFormatter(buf []byte, t time.Time) []byte {
+	hour := t.Hour() % 12
+	if hour == 0 {
+		hour = 12
 	}
-	return fmt.Sprintf("%02d", week)
+	return append(buf, fmt.Sprintf("%2d", hour)...)
 }
 
 // See http://docs.python.org/2/library/time.html#time.strftime
-var formats = map[string]FormatFunc{
-	"%a": func(t time.Time) string { // Locale’s abbreviated weekday name
-		return t.Format("Mon")
-	},
-	"%A": func(t time.Time) string { // Locale’s full weekday name
-		return t.Format("Monday")
-	},
-	"%b": func(t time.Time) string { // Locale’s abbreviated month name
-		return t.Format("Jan")
-	},
-	"%B": func(t time.Time) string { // Locale’s full month name
-		return t.Format("January")
-	},
-	"%c": func(t time.Time) string { // Locale’s appropriate date and time representation
-		return t.Format(time.RFC1123)
-	},
-	"%d": func(t time.Time) string { // Day of the month as a decimal number [01,31]
-		return t.Format("02")
-	},
-	"%H": func(t time.Time) string { // Hour (24-hour clock) as a decimal number [00,23]
-		return t.Format("15")
-	},
-	"%I": func(t time.Time) string { // Hour (12-hour clock) as a decimal number [01,12]
-		return t.Format("3")
-	},
-	"%j": func(t time.Time) string {
-		start := time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
-		day := int(t.Sub(start).Hours()/24) + 1
-		return fmt.Sprintf("%03d", day)
-	},
-	"%m": func(t time.Time) string { // Month as a decimal number [01,12]
-		return t.Format("01")
-	},
-	"%M": func(t time.Time) string { // Minute as a decimal number [00,59]
-		return t.Format("04")
-	},
-	"%p": func(t time.Time) string { // Locale’s equivalent of either AM or PM
-		return t.Format("PM")
-	},
-	"%S": func(t time.Time) string { // Second as a decimal number [00,61]
-		return t.Format("05")
-	},
-	"%U": weekNumberFormatter, // Week number of the year
-	"%W": weekNumberFormatter, // Week number of the year
-	"%w": func(t time.Time) string { // Weekday as a decimal number
-		return fmt.Sprintf("%d", t.Weekday())
-	},
-	"%x": func(t time.Time) string { // Locale’s appropriate date representation
-		return t.Format("01/02/06")
-	},
-	"%X": func(t time.Time) string { // Locale’s appropriate time representation
-		return t.Format("15:04:05")
-	},
-	"%y": func(t time.Time) string { // Year without century as a decimal number [00,99]
-		return t.Format("06")
-	},
-	"%Y": func(t time.Time) string { // Year with century as a decimal number
-		return t.Format("2006")
-	},
-	"%Z": func(t time.Time) string { // Time zone name (no characters if no time zone exists)
-		return t.Format("MST")
-	},
+func defaultSpecs() map[string]Appender {
+	return map[string]Appender{
+		"%a": func(buf []byte, t time.Time) []byte { // Locale’s abbreviated weekday name
+			return t.AppendFormat(buf, "Mon")
+		},
+		"%A": func(buf []byte, t time.Time) []byte { // Locale’s full weekday name
+			return t.AppendFormat(buf, "Monday")
+		},
+		"%b": func(buf []byte, t time.Time) []byte { // Locale’s abbreviated month name
+			return t.AppendFormat(buf, "Jan")
+		},
+		"%B": func(buf []byte, t time.Time) []byte { // Locale’s full month name
+			return t.AppendFormat(buf, "January")
+		},
+		"%c": func(buf []byte, t time.Time) []byte { // Locale’s appropriate date and time representation
+			return t.AppendFormat(buf, time.RFC1123)
+		},
+		"%C": func(buf []byte, t time.Time) []byte { // Century as a decimal number [00,99]
+			return append(buf, fmt.Sprintf("%02d", t.Year()/100)...)
+		},
+		"%d": func(buf []byte, t time.Time) []byte { // Day of the month as a decimal number [01,31]
+			return t.AppendFormat(buf, "02")
+		},
+		"%D": func(buf []byte, t time.Time) []byte { // Equivalent to %m/%d/%y
+			return t.AppendFormat(buf, "01/02/06")
+		},
+		"%e": func(buf []byte, t time.Time) []byte { // Day of the month, space padded [ 1,31]
+			return t.AppendFormat(buf, "_2")
+		},
+		"%F": func(buf []byte, t time.Time) []byte { // Equivalent to %Y-%m-%d
+			return t.AppendFormat(buf, "2006-01-02")
+		},
+		"%g": isoWeekYearShortFormatter, // ISO 8601 week-based year without century
+		"%G": isoWeekYearFormatter,      // ISO 8601 week-based year with century
+		"%h": func(buf []byte, t time.Time) []byte { // Equivalent to %b
+			return t.AppendFormat(buf, "Jan")
+		},
+		"%H": func(buf []byte, t time.Time) []byte { // Hour (24-hour clock) as a decimal number [00,23]
+			return t.AppendFormat(buf, "15")
+		},
+		"%I": func(buf []byte, t time.Time) []byte { // Hour (12-hour clock) as a decimal number [01,12]
+			return t.AppendFormat(buf, "03")
+		},
+		"%j": func(buf []byte, t time.Time) []byte { // Day of year
+			return append(buf, fmt.Sprintf("%03d", t.YearDay())...)
+		},
+		"%k": hour24SpacePaddedFormatter, // Hour (24-hour clock), space padded [ 0,23]
+		"%l": hour12SpacePaddedFormatter, // Hour (12-hour clock), space padded [ 1,12]
+		"%m": func(buf []byte, t time.Time) []byte { // Month as a decimal number [01,12]
+			return t.AppendFormat(buf, "01")
+		},
+		"%M": func(buf []byte, t time.Time) []byte { // Minute as a decimal number [00,59]
+			return t.AppendFormat(buf, "04")
+		},
+		"%n": func(buf []byte, t time.Time) []byte { // Newline
+			return append(buf, '\n')
+		},
+		"%p": func(buf []byte, t time.Time) []byte { // Locale’s equivalent of either AM or PM
+			return t.AppendFormat(buf, "PM")
+		},
+		"%P": func(buf []byte, t time.Time) []byte { // Like %p but lowercase
+			return t.AppendFormat(buf, "pm")
+		},
+		"%r": func(buf []byte, t time.Time) []byte { // Equivalent to %I:%M:%S %p
+			return t.AppendFormat(buf, "03:04:05 PM")
+		},
+		"%R": func(buf []byte, t time.Time) []byte { // Equivalent to %H:%M
+			return t.AppendFormat(buf, "15:04")
+		},
+		"%s": func(buf []byte, t time.Time) []byte { // Seconds since the Epoch
+			return strconv.AppendInt(buf, t.Unix(), 10)
+		},
+		"%S": func(buf []byte, t time.Time) []byte { // Second as a decimal number [00,61]
+			return t.AppendFormat(buf, "05")
+		},
+		"%t": func(buf []byte, t time.Time) []byte { // Tab
+			return append(buf, '\t')
+		},
+		"%T": func(buf []byte, t time.Time) []byte { // Equivalent to %H:%M:%S
+			return t.AppendFormat(buf, "15:04:05")
+		},
+		"%u": isoWeekdayFormatter,       // ISO 8601 weekday as a decimal number [1,7]
+		"%U": weekNumberSundayFormatter, // Week number of the year, Sunday first
+		"%v": func(buf []byte, t time.Time) []byte { // Equivalent to %e-%b-%Y
+			return t.AppendFormat(buf, "_2-Jan-2006")
+		},
+		"%V": isoWeekFormatter, // ISO 8601 week number
+		"%w": func(buf []byte, t time.Time) []byte { // Weekday as a decimal number
+			return append(buf, fmt.Sprintf("%d", t.Weekday())...)
+		},
+		"%W": weekNumberMondayFormatter, // Week number of the year, Monday first
+		"%x": func(buf []byte, t time.Time) []byte { // Locale’s appropriate date representation
+			return t.AppendFormat(buf, "01/02/06")
+		},
+		"%X": func(buf []byte, t time.Time) []byte { // Locale’s appropriate time representation
+			return t.AppendFormat(buf, "15:04:05")
+		},
+		"%y": func(buf []byte, t time.Time) []byte { // Year without century as a decimal number [00,99]
+			return t.AppendFormat(buf, "06")
+		},
+		"%Y": func(buf []byte, t time.Time) []byte { // Year with century as a decimal number
+			return t.AppendFormat(buf, "2006")
+		},
+		"%z": func(buf []byte, t time.Time) []byte { // Numeric time zone offset
+			return t.AppendFormat(buf, "-0700")
+		},
+		"%Z": func(buf []byte, t time.Time) []byte { // Time zone name (no characters if no time zone exists)
+			return t.AppendFormat(buf, "MST")
+		},
+	}
 }
 
-var (
-	//	fmtRe      = regexp.MustCompile("%([%aAbBcdHIjmMpSUwWxXyYZ]|[1-9]n)")
-	fmtRe          = initFormatRegexp()
-	fmtBackquoteRe = initFormatBackquoteRegexp()
-)
+// SpecificationSet is a registry of %X directives. The zero value is not
+// usable; construct one with NewSpecificationSet or DefaultSpecificationSet.
+//
+// A SpecificationSet is not safe for concurrent mutation (Set/Delete) while
+// it is being used to Format, but concurrent reads (Format calls against an
+// unmodified set) are fine.
+type SpecificationSet struct {
+	specs map[string]Appender
+}
+
+// defaultSpecificationSet backs the package-level Format/NewFormatter when
+// no WithSpecificationSet option is given.
+var defaultSpecificationSet = newSpecificationSet(defaultSpecs())
+
+// NewSpecificationSet returns an empty SpecificationSet with no directives
+// registered. Use Set to populate it, or start from DefaultSpecificationSet
+// if you only want to override or remove a handful of directives.
+func NewSpecificationSet() *SpecificationSet {
+	return newSpecificationSet(map[string]Appender{})
+}
+
+// DefaultSpecificationSet returns a copy of the built-in directive table, so
+// callers can add or replace directives without affecting Format's default
+// behavior.
+func DefaultSpecificationSet() *SpecificationSet {
+	return defaultSpecificationSet.Clone()
+}
+
+func newSpecificationSet(specs map[string]Appender) *SpecificationSet {
+	return &SpecificationSet{specs: specs}
+}
+
+// Clone returns an independent copy of s; mutating the copy does not affect s.
+func (s *SpecificationSet) Clone() *SpecificationSet {
+	specs := make(map[string]Appender, len(s.specs))
+	for spec, fn := range s.specs {
+		specs[spec] = fn
+	}
+	return newSpecificationSet(specs)
+}
+
+// Set registers fn as the handler for the single-character directive spec
+// (e.g. "%L"), replacing any existing handler for that directive.
+func (s *SpecificationSet) Set(spec string, fn Appender) {
+	s.specs[spec] = fn
+}
+
+// Delete disables spec, so Format/NewFormatter leave it unexpanded instead
+// of calling a handler for it.
+func (s *SpecificationSet) Delete(spec string) {
+	delete(s.specs, spec)
+}
+
+func (s *SpecificationSet) get(spec string) (Appender, bool) {
+	fn, ok := s.specs[spec]
+	return fn, ok
+}
+
+// Option configures Format, FormatTo and NewFormatter.
+type Option func(*options)
 
-func initFormatRegexp() *regexp.Regexp {
-	var buf bytes.Buffer
-	buf.WriteString("%([%")
-	for format, _ := range formats {
-		buf.WriteString(regexp.QuoteMeta(format[1:]))
+type options struct {
+	specs *SpecificationSet
+}
+
+// WithSpecificationSet makes Format/NewFormatter resolve %X directives
+// against set instead of the package default, so callers can register or
+// override directives without forking the package.
+func WithSpecificationSet(set *SpecificationSet) Option {
+	return func(o *options) {
+		o.specs = set
 	}
-	buf.WriteString("]|[1-9]n)")
-	re := buf.String()
-	return regexp.MustCompile(re)
 }
 
-func initFormatBackquoteRegexp() *regexp.Regexp {
-	var buf bytes.Buffer
-	buf.WriteString("%([^")
-	for format, _ := range formats {
-		buf.WriteString(regexp.QuoteMeta(format[1:]))
+func newOptions(opts []Option) *options {
+	o := &options{specs: defaultSpecificationSet}
+	for _, opt := range opts {
+		opt(o)
 	}
-	buf.WriteString("1-9]|[1-9][^n])")
-	re := buf.String()
-	return regexp.MustCompile(re)
+	return o
 }
 
 // A load from pkg/time/format.go of golang source code.
@@ -174,97 +374,260 @@ func formatNano(nanosec uint, n int, trim bool) []byte {
 	return buf[:n]
 }
 
-func formatNanoForMatch(match string, t time.Time) string {
-	// format nanosecond for a match format %[1-9]n
-	size := int(match[1] - '0')
-	return string(formatNano(uint(t.Nanosecond()), size, false))
+func isNanoSpec(spec string) bool {
+	return len(spec) == 3 && spec[0] == '%' && spec[1] >= '1' && spec[1] <= '9' && spec[2] == 'n'
 }
 
-// repl replaces % directives with right time
-func repl(match string, t time.Time) string {
-	if match == "%%" {
-		return "%"
+// applyModifier applies a POSIX/GNU padding or case flag, in place, to
+// buf[start:], which was just appended by the directive's Appender.
+func applyModifier(mod byte, buf []byte, start int) []byte {
+	segment := buf[start:]
+	switch mod {
+	case '-': // no padding
+		trimmed := bytes.TrimLeft(segment, "0 ")
+		if len(trimmed) == 0 {
+			// The Appender may have produced a zero-length segment (e.g. a
+			// custom %X directive that trims to "" for a zero value), in
+			// which case buf[start] doesn't exist yet; append instead of
+			// indexing into it.
+			if start < len(buf) {
+				buf[start] = '0'
+				return buf[:start+1]
+			}
+			return append(buf, '0')
+		}
+		copy(buf[start:], trimmed)
+		return buf[:start+len(trimmed)]
+	case '_': // space pad
+		padInPlace(segment, ' ')
+	case '0': // zero pad
+		padInPlace(segment, '0')
+	case '^': // upper-case result
+		upperInPlace(segment)
 	}
+	return buf
+}
 
-	formatFunc, ok := formats[match]
-	if ok {
-		return formatFunc(t)
+// padInPlace replaces the leading run of padding characters (spaces or
+// zeros) in b with fill, preserving b's width. It leaves the last byte
+// alone so an all-padding value (e.g. "00") still prints one digit.
+func padInPlace(b []byte, fill byte) {
+	for i := 0; i < len(b)-1; i++ {
+		if b[i] == '0' || b[i] == ' ' {
+			b[i] = fill
+		} else {
+			break
+		}
 	}
-	return formatNanoForMatch(match, t)
 }
 
-// Format return string with % directives expanded.
-// Will return error on unknown directive.
-func Format(format string, t time.Time) string {
-	fn := func(match string) string {
-		return repl(match, t)
+func upperInPlace(b []byte) {
+	for i, c := range b {
+		if c >= 'a' && c <= 'z' {
+			b[i] = c - ('a' - 'A')
+		}
 	}
-	return fmtRe.ReplaceAllStringFunc(format, fn)
 }
 
-func FormatTo(w io.Writer, format string, t time.Time) (n int, err error) {
-	result := Format(format, t)
-	return w.Write([]byte(result))
+// token is one piece of a parsed format: either a literal run of text, or a
+// directive (spec, with an optional modifier flag).
+type token struct {
+	literal  string
+	spec     string
+	modifier byte
 }
 
-type Formatter struct {
-	format     string
-	strFormat  string
-	formatFunc func(t time.Time) []interface{}
-}
+// tokenize splits format into literal runs and directives, consulting specs
+// to tell a known directive (with an optional POSIX/GNU flag, e.g. %-d)
+// from an unknown one, which is passed through unexpanded. This replaces a
+// single fixed regex, since whether %X is a directive now depends on a
+// variable-length prefix (the optional flag).
+func tokenize(specs *SpecificationSet, format string) []token {
+	var tokens []token
+	pos := 0
+	for pos < len(format) {
+		idx := strings.IndexByte(format[pos:], '%')
+		if idx < 0 {
+			tokens = append(tokens, token{literal: format[pos:]})
+			break
+		}
+		if idx > 0 {
+			tokens = append(tokens, token{literal: format[pos : pos+idx]})
+			pos += idx
+		}
+		if pos+1 >= len(format) {
+			tokens = append(tokens, token{literal: format[pos:]})
+			break
+		}
+		if format[pos+1] == '%' {
+			tokens = append(tokens, token{spec: "%%"})
+			pos += 2
+			continue
+		}
 
-func NewFormatter(format string) *Formatter {
-	f := func(match string) string {
-		if match == "%%" {
-			return match
+		specStart := pos + 1
+		var mod byte
+		if c := format[pos+1]; c == '-' || c == '_' || c == '0' || c == '^' {
+			if pos+2 < len(format) {
+				mod = c
+				specStart = pos + 2
+			}
 		}
-		return "%" + match
-	}
-	strFormat := fmtBackquoteRe.ReplaceAllStringFunc(format, f)
-	size := 0
-	f1 := func(match string) string {
-		if match == "%%" {
-			return match
+		if specStart >= len(format) {
+			tokens = append(tokens, token{literal: format[pos:]})
+			break
 		}
-		size++
-		return "%s"
-	}
-	strFormat = fmtRe.ReplaceAllStringFunc(strFormat, f1)
-	funs := make([]FormatFunc, 0, size)
-	f2 := func(match string) string {
-		if match == "%%" {
-			return match
+
+		if mod == 0 && specStart+1 < len(format) && format[specStart] >= '1' && format[specStart] <= '9' && format[specStart+1] == 'n' {
+			tokens = append(tokens, token{spec: format[pos : specStart+2]})
+			pos = specStart + 2
+			continue
+		}
+
+		spec := "%" + format[specStart:specStart+1]
+		if _, ok := specs.get(spec); ok {
+			tokens = append(tokens, token{spec: spec, modifier: mod})
+			pos = specStart + 1
+			continue
 		}
-		f, ok := formats[match]
-		if ok {
-			funs = append(funs, f)
+
+		// Unknown directive: pass the '%' (and any flag) through as a literal.
+		tokens = append(tokens, token{literal: format[pos : specStart+1]})
+		pos = specStart + 1
+	}
+	return tokens
+}
+
+// appendToken expands tok against t, honoring any POSIX/GNU modifier, and
+// appends the result to buf.
+func appendToken(specs *SpecificationSet, tok token, t time.Time, buf []byte) []byte {
+	if tok.spec == "" {
+		return append(buf, tok.literal...)
+	}
+	if tok.spec == "%%" {
+		return append(buf, '%')
+	}
+
+	start := len(buf)
+	switch {
+	case isNanoSpec(tok.spec):
+		size := int(tok.spec[1] - '0')
+		buf = append(buf, formatNano(uint(t.Nanosecond()), size, false)...)
+	default:
+		if fn, ok := specs.get(tok.spec); ok {
+			buf = fn(buf, t)
 		} else {
-			f := func(t time.Time) string {
-				return formatNanoForMatch(match, t)
-			}
-			funs = append(funs, f)
+			buf = append(buf, tok.spec...)
 		}
-		return match
 	}
-	fmtRe.ReplaceAllStringFunc(format, f2)
-	formatFunc := func(t time.Time) []interface{} {
-		result := make([]interface{}, 0, len(funs))
-		for _, f := range funs {
-			result = append(result, f(t))
+	if tok.modifier != 0 {
+		buf = applyModifier(tok.modifier, buf, start)
+	}
+	return buf
+}
+
+// scratchPool holds reusable byte slices for FormatTo/Formatter.FormatTo, so
+// writing to an io.Writer doesn't allocate a fresh buffer on every call.
+var scratchPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 64)
+		return &b
+	},
+}
+
+// AppendFormat appends the expansion of format to dst and returns the
+// extended buffer, following the convention of time.Time.AppendFormat. It
+// is the building block behind Format and FormatTo. When dst has room, the
+// output goes into dst's backing array; splitting format into directives
+// still allocates on each call, as do directives built on fmt.Sprintf
+// (such as %C, %j, %U and %V).
+func AppendFormat(dst []byte, format string, t time.Time, opts ...Option) []byte {
+	o := newOptions(opts)
+	for _, tok := range tokenize(o.specs, format) {
+		dst = appendToken(o.specs, tok, t, dst)
+	}
+	return dst
+}
+
+// Format return string with % directives expanded.
+// Unknown directives are copied to the result unexpanded.
+func Format(format string, t time.Time, opts ...Option) string {
+	return string(AppendFormat(nil, format, t, opts...))
+}
+
+func FormatTo(w io.Writer, format string, t time.Time, opts ...Option) (n int, err error) {
+	bufp := scratchPool.Get().(*[]byte)
+	buf := AppendFormat((*bufp)[:0], format, t, opts...)
+	n, err = w.Write(buf)
+	*bufp = buf
+	scratchPool.Put(bufp)
+	return n, err
+}
+
+// formatterPart is one precompiled piece of a Formatter: either a literal
+// byte run, or an append closure bound to a single directive token.
+type formatterPart struct {
+	literal []byte
+	append  func(buf []byte, t time.Time) []byte
+}
+
+type Formatter struct {
+	format string
+	parts  []formatterPart
+}
+
+func NewFormatter(format string, opts ...Option) *Formatter {
+	o := newOptions(opts)
+	specs := o.specs
+	tokens := tokenize(specs, format)
+
+	parts := make([]formatterPart, 0, len(tokens))
+	for _, tok := range tokens {
+		if tok.spec == "" {
+			parts = append(parts, formatterPart{literal: []byte(tok.literal)})
+			continue
 		}
-		return result
+		tok := tok
+		parts = append(parts, formatterPart{append: func(buf []byte, t time.Time) []byte {
+			return appendToken(specs, tok, t, buf)
+		}})
 	}
-	return &Formatter{
-		format:     format,
-		strFormat:  strFormat,
-		formatFunc: formatFunc,
+	return &Formatter{format: format, parts: parts}
+}
+
+// appendFormat appends self's expansion at t to dst, in a single pass over
+// its precompiled parts.
+func (self *Formatter) appendFormat(dst []byte, t time.Time) []byte {
+	for _, part := range self.parts {
+		if part.append != nil {
+			dst = part.append(dst, t)
+		} else {
+			dst = append(dst, part.literal...)
+		}
 	}
+	return dst
 }
 
+// Format expands self at t into a new string. It reuses a pooled scratch
+// buffer (the same one FormatTo draws from) to build the result, so
+// repeated calls settle into a single allocation per call -- the final
+// string conversion -- instead of reallocating the buffer from nil each
+// time. Directives built on fmt.Sprintf (such as %j and %U) add
+// allocations of their own on every call.
 func (self *Formatter) Format(t time.Time) string {
-	return fmt.Sprintf(self.strFormat, self.formatFunc(t)...)
+	bufp := scratchPool.Get().(*[]byte)
+	buf := self.appendFormat((*bufp)[:0], t)
+	s := string(buf)
+	*bufp = buf
+	scratchPool.Put(bufp)
+	return s
 }
 
 func (self *Formatter) FormatTo(w io.Writer, t time.Time) (n int, err error) {
-	return fmt.Fprintf(w, self.strFormat, self.formatFunc(t)...)
+	bufp := scratchPool.Get().(*[]byte)
+	buf := self.appendFormat((*bufp)[:0], t)
+	n, err = w.Write(buf)
+	*bufp = buf
+	scratchPool.Put(bufp)
+	return n, err
 }
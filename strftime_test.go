@@ -2,6 +2,7 @@ package strftime
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 	"time"
 )
@@ -37,6 +38,32 @@ var testCases = []*TestCase{
 	&TestCase{"%6n", "000000"},
 	&TestCase{"%9n", "000000003"},
 
+	// POSIX/GNU specifiers
+	&TestCase{"%e", "10"},
+	&TestCase{"%h", "Nov"},
+	&TestCase{"%k", "23"},
+	&TestCase{"%l", "11"},
+	&TestCase{"%n", "\n"},
+	&TestCase{"%t", "\t"},
+	&TestCase{"%r", "11:01:02 PM"},
+	&TestCase{"%R", "23:01"},
+	&TestCase{"%T", "23:01:02"},
+	&TestCase{"%F", "2009-11-10"},
+	&TestCase{"%D", "11/10/09"},
+	&TestCase{"%v", "10-Nov-2009"},
+	&TestCase{"%s", "1257894062"},
+	&TestCase{"%z", "+0000"},
+	&TestCase{"%C", "20"},
+	&TestCase{"%G", "2009"},
+	&TestCase{"%g", "09"},
+	&TestCase{"%V", "46"},
+	&TestCase{"%u", "2"},
+	&TestCase{"%P", "pm"},
+
+	// POSIX/GNU case modifier
+	&TestCase{"%^a", "TUE"},
+	&TestCase{"%^b", "NOV"},
+
 	// Escape
 	&TestCase{"%%%Y", "%2009"},
 	&TestCase{"%3%%", "%3%"},
@@ -58,7 +85,7 @@ func TestFormats(t *testing.T) {
 }
 
 func TestUnknown(t *testing.T) {
-	unknownFormat := "%g"
+	unknownFormat := "%q"
 	value := Format(unknownFormat, testTime)
 	if unknownFormat != value {
 		t.Fatalf("error to in %s: got %s instead of %s", unknownFormat, value, unknownFormat)
@@ -81,7 +108,7 @@ func TestFormatter_ValidFormats(t *testing.T) {
 }
 
 func TestFormatter_InvalidFormats(t *testing.T) {
-	unknownFormat := "%g"
+	unknownFormat := "%q"
 	formatter := NewFormatter(unknownFormat)
 	value := formatter.Format(testTime)
 	if unknownFormat != value {
@@ -93,3 +120,167 @@ func TestFormatter_InvalidFormats(t *testing.T) {
 		t.Fatalf("error to in %s: get %s instead of %s", unknownFormat, value, unknownFormat)
 	}
 }
+
+// TestFormatter_FormatAllocs checks that Format settles into a single
+// allocation per call -- the returned string's copy -- once its pooled
+// scratch buffer has grown large enough, instead of rebuilding from nil
+// on every call.
+func TestFormatter_FormatAllocs(t *testing.T) {
+	formatter := NewFormatter("%a, %d %b %Y %H:%M:%S %z")
+	formatter.Format(testTime) // warm up the pooled scratch buffer
+
+	allocs := testing.AllocsPerRun(100, func() {
+		formatter.Format(testTime)
+	})
+	if allocs > 1 {
+		t.Fatalf("Formatter.Format: got %v allocs/op, want at most 1", allocs)
+	}
+}
+
+func TestAppendFormat(t *testing.T) {
+	dst := make([]byte, 0, 64)
+	dst = append(dst, "prefix-"...)
+	before := &dst[:1][0]
+	got := AppendFormat(dst, "%Y-%m-%d", testTime)
+	want := "prefix-2009-11-10"
+	if string(got) != want {
+		t.Fatalf("got %q instead of %q", got, want)
+	}
+	// Given enough capacity, AppendFormat must append into dst's backing
+	// array rather than allocating a fresh one.
+	if &got[:1][0] != before {
+		t.Fatalf("AppendFormat did not append into the provided buffer")
+	}
+}
+
+func TestWeekNumbers_YearBoundaries(t *testing.T) {
+	cases := []struct {
+		date              time.Time
+		u, w, v, j, g, gc string
+	}{
+		// 2005-01-01 is a Saturday: before the year's first Sunday and
+		// first Monday alike, but already in ISO week 53 of 2004.
+		{time.Date(2005, time.January, 1, 0, 0, 0, 0, time.UTC), "00", "00", "53", "001", "04", "2004"},
+		// 2007-12-31 is a Monday: %U and %W disagree because %U only
+		// advances on Sundays.
+		{time.Date(2007, time.December, 31, 0, 0, 0, 0, time.UTC), "52", "53", "01", "365", "08", "2008"},
+		// 2020-01-01 is a Wednesday, in ISO week 1 of 2020 (2020-01-02 is
+		// the year's first Thursday) but week 00 under both POSIX rules.
+		{time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), "00", "00", "01", "001", "20", "2020"},
+	}
+	for _, tc := range cases {
+		if got := Format("%U", tc.date); got != tc.u {
+			t.Errorf("%%U for %s: got %s, want %s", tc.date.Format("2006-01-02"), got, tc.u)
+		}
+		if got := Format("%W", tc.date); got != tc.w {
+			t.Errorf("%%W for %s: got %s, want %s", tc.date.Format("2006-01-02"), got, tc.w)
+		}
+		if got := Format("%V", tc.date); got != tc.v {
+			t.Errorf("%%V for %s: got %s, want %s", tc.date.Format("2006-01-02"), got, tc.v)
+		}
+		if got := Format("%j", tc.date); got != tc.j {
+			t.Errorf("%%j for %s: got %s, want %s", tc.date.Format("2006-01-02"), got, tc.j)
+		}
+		if got := Format("%g", tc.date); got != tc.g {
+			t.Errorf("%%g for %s: got %s, want %s", tc.date.Format("2006-01-02"), got, tc.g)
+		}
+		if got := Format("%G", tc.date); got != tc.gc {
+			t.Errorf("%%G for %s: got %s, want %s", tc.date.Format("2006-01-02"), got, tc.gc)
+		}
+	}
+}
+
+func TestPaddingModifiers(t *testing.T) {
+	paddingTime := time.Date(2009, time.January, 3, 5, 1, 2, 3, time.UTC)
+	cases := []*TestCase{
+		{"%d", "03"},
+		{"%-d", "3"},
+		{"%_d", " 3"},
+		{"%e", " 3"},
+		{"%0e", "03"},
+		{"%H", "05"},
+		{"%-H", "5"},
+		{"%k", " 5"},
+		{"%0k", "05"},
+	}
+	for _, tc := range cases {
+		value := Format(tc.format, paddingTime)
+		if value != tc.value {
+			t.Fatalf("error in %s: got %q instead of %q", tc.format, value, tc.value)
+		}
+	}
+}
+
+func TestFormat_TwelveHourZeroPadded(t *testing.T) {
+	singleDigitHour := time.Date(2009, time.November, 10, 3, 5, 6, 0, time.UTC)
+
+	if got := Format("%I", singleDigitHour); got != "03" {
+		t.Fatalf("%%I: got %q instead of %q", got, "03")
+	}
+	if got, want := Format("%r", singleDigitHour), Format("%I:%M:%S %p", singleDigitHour); got != want {
+		t.Fatalf("%%r and %%I:%%M:%%S %%p disagree: %q vs %q", got, want)
+	}
+}
+
+func TestSpecificationSet_CustomDirective(t *testing.T) {
+	set := DefaultSpecificationSet()
+	set.Set("%L", func(buf []byte, t time.Time) []byte {
+		return append(buf, fmt.Sprintf("%03d", t.Nanosecond()/1e6)...)
+	})
+
+	value := Format("%Y-%L", testTime, WithSpecificationSet(set))
+	if value != "2009-000" {
+		t.Fatalf("got %s instead of %s", value, "2009-000")
+	}
+
+	formatter := NewFormatter("%Y-%L", WithSpecificationSet(set))
+	value = formatter.Format(testTime)
+	if value != "2009-000" {
+		t.Fatalf("got %s instead of %s", value, "2009-000")
+	}
+}
+
+func TestModifier_DashOnEmptyAppenderResult(t *testing.T) {
+	set := DefaultSpecificationSet()
+	set.Set("%L", func(buf []byte, t time.Time) []byte {
+		return buf // appends nothing, like a directive that trims to ""
+	})
+
+	value := Format("%-L", testTime, WithSpecificationSet(set))
+	if value != "0" {
+		t.Fatalf("got %q instead of %q", value, "0")
+	}
+
+	formatter := NewFormatter("%-L", WithSpecificationSet(set))
+	value = formatter.Format(testTime)
+	if value != "0" {
+		t.Fatalf("got %q instead of %q", value, "0")
+	}
+}
+
+func TestSpecificationSet_OverrideAndDelete(t *testing.T) {
+	set := DefaultSpecificationSet()
+	set.Set("%p", func(buf []byte, t time.Time) []byte {
+		return append(buf, []byte("pm")...)
+	})
+	value := Format("%p", testTime, WithSpecificationSet(set))
+	if value != "pm" {
+		t.Fatalf("got %s instead of %s", value, "pm")
+	}
+
+	set.Delete("%p")
+	value = Format("%p", testTime, WithSpecificationSet(set))
+	if value != "%p" {
+		t.Fatalf("got %s instead of %s", value, "%p")
+	}
+}
+
+func TestSpecificationSet_CloneIsIndependent(t *testing.T) {
+	clone := DefaultSpecificationSet()
+	clone.Delete("%Y")
+
+	value := Format("%Y", testTime)
+	if value != "2009" {
+		t.Fatalf("deleting from a clone affected the default set: got %s instead of %s", value, "2009")
+	}
+}